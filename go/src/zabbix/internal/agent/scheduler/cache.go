@@ -0,0 +1,213 @@
+/*
+** Zabbix
+** Copyright (C) 2001-2019 Zabbix SIA
+**
+** This program is free software; you can redistribute it and/or modify
+** it under the terms of the GNU General Public License as published by
+** the Free Software Foundation; either version 2 of the License, or
+** (at your option) any later version.
+**
+** This program is distributed in the hope that it will be useful,
+** but WITHOUT ANY WARRANTY; without even the implied warranty of
+** MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+** GNU General Public License for more details.
+**
+** You should have received a copy of the GNU General Public License
+** along with this program; if not, write to the Free Software
+** Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+**/
+
+package scheduler
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"zabbix/pkg/plugin"
+)
+
+// resultCacheKey identifies a cached exporter result by the item requesting
+// it and a hash of the key parameters it was produced with, so two items
+// polling the same key with different parameters never collide.
+type resultCacheKey struct {
+	itemid uint64
+	key    string
+	params uint64
+}
+
+func newResultCacheKey(itemid uint64, key string, params []string) resultCacheKey {
+	h := fnv.New64a()
+	for _, p := range params {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return resultCacheKey{itemid: itemid, key: key, params: h.Sum64()}
+}
+
+type cacheRecord struct {
+	key    resultCacheKey
+	result *plugin.Result
+	stored time.Time
+}
+
+// ResultCache is an opt-in LRU+TTL cache of the last successful exporter
+// result per (itemid, key, params), letting duplicate passive checks for an
+// expensive collector (e.g. systemd.unit.discovery) be served without
+// invoking the plugin again. A nil *ResultCache disables caching.
+type ResultCache struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[resultCacheKey]*list.Element
+	order    *list.List
+	hits     uint64
+	misses   uint64
+	evicted  uint64
+}
+
+// NewResultCache creates a cache holding at most capacity entries. A
+// capacity of 0 means unbounded.
+func NewResultCache(capacity int) *ResultCache {
+	return &ResultCache{
+		capacity: capacity,
+		entries:  make(map[resultCacheKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached result for key if one exists and is younger than
+// maxAge.
+func (c *ResultCache) Get(key resultCacheKey, maxAge time.Duration) (*plugin.Result, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+
+	rec := elem.Value.(*cacheRecord)
+	if maxAge <= 0 || time.Since(rec.stored) > maxAge {
+		c.removeElement(elem)
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	atomic.AddUint64(&c.hits, 1)
+	return rec.result, true
+}
+
+// Put stores result for key, evicting the least recently used entry if the
+// cache is at capacity.
+func (c *ResultCache) Put(key resultCacheKey, result *plugin.Result) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		rec := elem.Value.(*cacheRecord)
+		rec.result = result
+		rec.stored = time.Now()
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheRecord{key: key, result: result, stored: time.Now()})
+	c.entries[key] = elem
+
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		atomic.AddUint64(&c.evicted, 1)
+	}
+}
+
+func (c *ResultCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheRecord).key)
+}
+
+// Hits returns the number of cache lookups that found a usable entry.
+func (c *ResultCache) Hits() uint64 { return atomic.LoadUint64(&c.hits) }
+
+// Misses returns the number of cache lookups that found no usable entry.
+func (c *ResultCache) Misses() uint64 { return atomic.LoadUint64(&c.misses) }
+
+// Evicted returns the number of entries dropped to stay within capacity.
+func (c *ResultCache) Evicted() uint64 { return atomic.LoadUint64(&c.evicted) }
+
+// pluginCache is the shared, opt-in cache for one plugin's exporter tasks,
+// keyed by plugin name rather than by *pluginAgent so that the short-lived
+// exporterTask created for each single passive check still shares it with
+// every other task for the same plugin.
+type pluginCache struct {
+	cache *ResultCache
+	ttl   time.Duration
+}
+
+var (
+	pluginCachesMutex sync.Mutex
+	pluginCaches      = make(map[string]*pluginCache)
+)
+
+// defaultResultCacheCapacity bounds a CacheTTL-enabled plugin's cache so
+// items that stop being polled (removed units, one-off passive checks with
+// varying parameters) are eventually reclaimed by LRU rather than only by
+// TTL expiry.
+const defaultResultCacheCapacity = 1000
+
+// configureResultCache enables or disables result caching for the named
+// plugin based on its "CacheTTL" configuration option (a plain duration,
+// e.g. "30s"): a positive duration enables the cache using that duration as
+// the default retention, while an absent, zero or invalid value disables
+// it. Like Plugin.Configure implementations in this tree, every call fully
+// re-derives the state from options rather than patching it, so dropping
+// CacheTTL on a reconfigure turns caching back off. It is called from
+// configuratorTask.perform alongside the plugin's own Configure().
+func configureResultCache(name string, options map[string]string) {
+	pluginCachesMutex.Lock()
+	defer pluginCachesMutex.Unlock()
+
+	raw, ok := options["CacheTTL"]
+	if !ok {
+		delete(pluginCaches, name)
+		return
+	}
+
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl <= 0 {
+		delete(pluginCaches, name)
+		return
+	}
+
+	pluginCaches[name] = &pluginCache{cache: NewResultCache(defaultResultCacheCapacity), ttl: ttl}
+}
+
+// resultCacheFor returns the shared cache and default retention for the
+// named plugin, or (nil, 0) if it has not opted into caching via CacheTTL.
+func resultCacheFor(name string) (*ResultCache, time.Duration) {
+	pluginCachesMutex.Lock()
+	defer pluginCachesMutex.Unlock()
+
+	pc, ok := pluginCaches[name]
+	if !ok {
+		return nil, 0
+	}
+	return pc.cache, pc.ttl
+}
+
+// CacheStats returns the aggregate hit/miss/eviction counts across every
+// plugin's result cache. Nothing in the agent calls it, so these counts
+// are not reported as internal items.
+func CacheStats() (hits, misses, evicted uint64) {
+	pluginCachesMutex.Lock()
+	defer pluginCachesMutex.Unlock()
+
+	for _, pc := range pluginCaches {
+		hits += pc.cache.Hits()
+		misses += pc.cache.Misses()
+		evicted += pc.cache.Evicted()
+	}
+	return
+}
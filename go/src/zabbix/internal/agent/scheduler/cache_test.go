@@ -0,0 +1,125 @@
+/*
+** Zabbix
+** Copyright (C) 2001-2019 Zabbix SIA
+**
+** This program is free software; you can redistribute it and/or modify
+** it under the terms of the GNU General Public License as published by
+** the Free Software Foundation; either version 2 of the License, or
+** (at your option) any later version.
+**
+** This program is distributed in the hope that it will be useful,
+** but WITHOUT ANY WARRANTY; without even the implied warranty of
+** MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+** GNU General Public License for more details.
+**
+** You should have received a copy of the GNU General Public License
+** along with this program; if not, write to the Free Software
+** Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+**/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"zabbix/pkg/plugin"
+)
+
+func TestResultCacheGetMiss(t *testing.T) {
+	c := NewResultCache(0)
+
+	if _, ok := c.Get(newResultCacheKey(1, "key", nil), time.Minute); ok {
+		t.Fatalf("expected miss on empty cache")
+	}
+	if c.Misses() != 1 || c.Hits() != 0 {
+		t.Fatalf("unexpected counters: hits=%d misses=%d", c.Hits(), c.Misses())
+	}
+}
+
+func TestResultCacheGetPutHit(t *testing.T) {
+	c := NewResultCache(0)
+	key := newResultCacheKey(1, "key", []string{"a"})
+	result := &plugin.Result{Itemid: 1}
+
+	c.Put(key, result)
+
+	got, ok := c.Get(key, time.Minute)
+	if !ok || got != result {
+		t.Fatalf("expected cached result to be returned")
+	}
+	if c.Hits() != 1 || c.Misses() != 0 {
+		t.Fatalf("unexpected counters: hits=%d misses=%d", c.Hits(), c.Misses())
+	}
+}
+
+func TestResultCacheDifferentParamsDontCollide(t *testing.T) {
+	c := NewResultCache(0)
+	keyA := newResultCacheKey(1, "key", []string{"a"})
+	keyB := newResultCacheKey(1, "key", []string{"b"})
+
+	c.Put(keyA, &plugin.Result{Itemid: 1})
+
+	if _, ok := c.Get(keyB, time.Minute); ok {
+		t.Fatalf("expected miss for a different params hash")
+	}
+}
+
+func TestResultCacheExpiry(t *testing.T) {
+	c := NewResultCache(0)
+	key := newResultCacheKey(1, "key", nil)
+	c.Put(key, &plugin.Result{Itemid: 1})
+
+	if _, ok := c.Get(key, -time.Second); ok {
+		t.Fatalf("expected an already-stale entry to miss")
+	}
+	if _, ok := c.Get(key, time.Minute); ok {
+		t.Fatalf("expected expired entry to have been evicted on the prior Get")
+	}
+}
+
+func TestResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewResultCache(2)
+	keyA := newResultCacheKey(1, "key", []string{"a"})
+	keyB := newResultCacheKey(2, "key", []string{"b"})
+	keyC := newResultCacheKey(3, "key", []string{"c"})
+
+	c.Put(keyA, &plugin.Result{Itemid: 1})
+	c.Put(keyB, &plugin.Result{Itemid: 2})
+	c.Put(keyC, &plugin.Result{Itemid: 3})
+
+	if _, ok := c.Get(keyA, time.Minute); ok {
+		t.Fatalf("expected the least recently used entry to have been evicted")
+	}
+	if c.Evicted() != 1 {
+		t.Fatalf("expected 1 eviction, got %d", c.Evicted())
+	}
+	if _, ok := c.Get(keyB, time.Minute); !ok {
+		t.Fatalf("expected keyB to still be cached")
+	}
+	if _, ok := c.Get(keyC, time.Minute); !ok {
+		t.Fatalf("expected keyC to still be cached")
+	}
+}
+
+func TestConfigureResultCache(t *testing.T) {
+	defer delete(pluginCaches, "test.plugin")
+
+	configureResultCache("test.plugin", map[string]string{"CacheTTL": "30s"})
+
+	cache, ttl := resultCacheFor("test.plugin")
+	if cache == nil || ttl != 30*time.Second {
+		t.Fatalf("expected caching enabled with a 30s ttl, got cache=%v ttl=%s", cache, ttl)
+	}
+
+	configureResultCache("test.plugin", map[string]string{"CacheTTL": "0"})
+	if cache, _ := resultCacheFor("test.plugin"); cache != nil {
+		t.Fatalf("expected CacheTTL=0 to disable caching")
+	}
+
+	configureResultCache("test.plugin", map[string]string{"CacheTTL": "30s"})
+	configureResultCache("test.plugin", map[string]string{})
+	if cache, _ := resultCacheFor("test.plugin"); cache != nil {
+		t.Fatalf("expected dropping the CacheTTL option on reconfigure to disable caching, not leave it stale")
+	}
+}
@@ -0,0 +1,161 @@
+/*
+** Zabbix
+** Copyright (C) 2001-2019 Zabbix SIA
+**
+** This program is free software; you can redistribute it and/or modify
+** it under the terms of the GNU General Public License as published by
+** the Free Software Foundation; either version 2 of the License, or
+** (at your option) any later version.
+**
+** This program is distributed in the hope that it will be useful,
+** but WITHOUT ANY WARRANTY; without even the implied warranty of
+** MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+** GNU General Public License for more details.
+**
+** You should have received a copy of the GNU General Public License
+** along with this program; if not, write to the Free Software
+** Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+**/
+
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"zabbix/pkg/log"
+)
+
+// logLevel mirrors the severity ordering of zabbix/pkg/log's own levels, so
+// a plugin's configured minimum can be compared against the level of a
+// given call.
+type logLevel int
+
+const (
+	logLevelTrace logLevel = iota
+	logLevelDebug
+	logLevelInfo
+	logLevelWarning
+	logLevelErr
+)
+
+var logLevelNames = map[string]logLevel{
+	"trace":   logLevelTrace,
+	"debug":   logLevelDebug,
+	"info":    logLevelInfo,
+	"warning": logLevelWarning,
+	"err":     logLevelErr,
+}
+
+var (
+	pluginLogLevelsMutex sync.Mutex
+	pluginLogLevels      = make(map[string]logLevel)
+)
+
+// configurePluginLogLevel sets or clears the named plugin's minimum log
+// level from its "LogLevel" configuration option (one of trace, debug,
+// info, warning, err), letting a single noisy plugin (e.g. systemd polling
+// hundreds of units) be turned down without touching the agent's global
+// LogLevel. Like configureResultCache, every call fully re-derives the
+// state: an absent or unrecognised value clears the override, so every
+// line is forwarded again regardless of its level.
+//
+// This only filters which lines reach the shared agent log, not which file
+// they land in: real per-plugin destinations would need a plugin.Logger
+// exposed via plugin.ContextProvider.Logger(), and zabbix.com/pkg/plugin is
+// not part of this source tree to extend.
+func configurePluginLogLevel(name string, options map[string]string) {
+	pluginLogLevelsMutex.Lock()
+	defer pluginLogLevelsMutex.Unlock()
+
+	raw, ok := options["LogLevel"]
+	if !ok {
+		delete(pluginLogLevels, name)
+		return
+	}
+
+	level, ok := logLevelNames[strings.ToLower(raw)]
+	if !ok {
+		delete(pluginLogLevels, name)
+		return
+	}
+
+	pluginLogLevels[name] = level
+}
+
+func pluginLogLevel(name string) (logLevel, bool) {
+	pluginLogLevelsMutex.Lock()
+	defer pluginLogLevelsMutex.Unlock()
+
+	level, ok := pluginLogLevels[name]
+	return level, ok
+}
+
+// taskLogger binds a plugin/item/key/client identity to every line it logs,
+// so a single noisy plugin (e.g. systemd polling hundreds of units) can be
+// filtered or aggregated on those fields instead of drowning out the rest
+// of the shared agent log. It uses the global log package as its sink, but
+// honours a per-plugin LogLevel override set via configurePluginLogLevel.
+type taskLogger struct {
+	plugin   string
+	itemid   uint64
+	key      string
+	clientid uint64
+}
+
+func (l *taskLogger) fields() string {
+	s := fmt.Sprintf("plugin=%s ", l.plugin)
+	if l.key != "" {
+		s += fmt.Sprintf("itemid=%d key=%s ", l.itemid, l.key)
+	}
+	if l.clientid != 0 {
+		s += fmt.Sprintf("client=%d ", l.clientid)
+	}
+	return s
+}
+
+func (l *taskLogger) enabled(level logLevel) bool {
+	min, ok := pluginLogLevel(l.plugin)
+	return !ok || level >= min
+}
+
+func (l *taskLogger) Tracef(format string, args ...interface{}) {
+	if !l.enabled(logLevelTrace) {
+		return
+	}
+	log.Tracef(l.fields()+format, args...)
+}
+
+func (l *taskLogger) Debugf(format string, args ...interface{}) {
+	if !l.enabled(logLevelDebug) {
+		return
+	}
+	log.Debugf(l.fields()+format, args...)
+}
+
+func (l *taskLogger) Infof(format string, args ...interface{}) {
+	if !l.enabled(logLevelInfo) {
+		return
+	}
+	log.Infof(l.fields()+format, args...)
+}
+
+func (l *taskLogger) Warningf(format string, args ...interface{}) {
+	if !l.enabled(logLevelWarning) {
+		return
+	}
+	log.Warningf(l.fields()+format, args...)
+}
+
+func (l *taskLogger) Errf(format string, args ...interface{}) {
+	if !l.enabled(logLevelErr) {
+		return
+	}
+	log.Errf(l.fields()+format, args...)
+}
+
+// logger returns a taskLogger bound only to the owning plugin's name, for
+// task types that have no per-item identity (collector/starter/stopper).
+func (t *taskBase) logger() *taskLogger {
+	return &taskLogger{plugin: t.plugin.impl.Name()}
+}
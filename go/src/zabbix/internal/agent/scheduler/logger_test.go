@@ -0,0 +1,59 @@
+/*
+** Zabbix
+** Copyright (C) 2001-2019 Zabbix SIA
+**
+** This program is free software; you can redistribute it and/or modify
+** it under the terms of the GNU General Public License as published by
+** the Free Software Foundation; either version 2 of the License, or
+** (at your option) any later version.
+**
+** This program is distributed in the hope that it will be useful,
+** but WITHOUT ANY WARRANTY; without even the implied warranty of
+** MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+** GNU General Public License for more details.
+**
+** You should have received a copy of the GNU General Public License
+** along with this program; if not, write to the Free Software
+** Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301, USA.
+**/
+
+package scheduler
+
+import "testing"
+
+func TestConfigurePluginLogLevel(t *testing.T) {
+	defer delete(pluginLogLevels, "test.plugin")
+
+	configurePluginLogLevel("test.plugin", map[string]string{"LogLevel": "warning"})
+
+	level, ok := pluginLogLevel("test.plugin")
+	if !ok || level != logLevelWarning {
+		t.Fatalf("expected warning override, got level=%v ok=%v", level, ok)
+	}
+
+	logger := &taskLogger{plugin: "test.plugin"}
+	if logger.enabled(logLevelDebug) {
+		t.Fatalf("expected debug to be filtered out below a warning override")
+	}
+	if !logger.enabled(logLevelErr) {
+		t.Fatalf("expected err to still pass a warning override")
+	}
+
+	configurePluginLogLevel("test.plugin", map[string]string{"LogLevel": "bogus"})
+	if _, ok := pluginLogLevel("test.plugin"); ok {
+		t.Fatalf("expected an unrecognised LogLevel to clear the override")
+	}
+
+	configurePluginLogLevel("test.plugin", map[string]string{"LogLevel": "warning"})
+	configurePluginLogLevel("test.plugin", map[string]string{})
+	if _, ok := pluginLogLevel("test.plugin"); ok {
+		t.Fatalf("expected dropping LogLevel on reconfigure to clear the override, not leave it stale")
+	}
+}
+
+func TestTaskLoggerEnabledWithoutOverride(t *testing.T) {
+	logger := &taskLogger{plugin: "unconfigured.plugin"}
+	if !logger.enabled(logLevelTrace) {
+		t.Fatalf("expected every level to pass when no override is configured")
+	}
+}
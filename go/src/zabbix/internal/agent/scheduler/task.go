@@ -20,12 +20,15 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"zabbix/pkg/itemutil"
-	"zabbix/pkg/log"
 	"zabbix/pkg/plugin"
 	"zabbix/pkg/zbxlib"
 )
@@ -40,14 +43,67 @@ const (
 	priorityStopperTaskNs
 )
 
+// maxExecuteTimeout bounds how long a single task invocation may run before
+// the scheduler gives up waiting for the plugin and reports ErrTimeout.
+const maxExecuteTimeout = 30 * time.Second
+
+// ErrTimeout is written to a task's output when its deadline elapses before
+// the underlying plugin call returns.
+var ErrTimeout = errors.New("timed out waiting for plugin to respond")
+
+// boundTimeout clamps d to (0, maxExecuteTimeout], falling back to
+// maxExecuteTimeout for an invalid or unbounded duration.
+func boundTimeout(d time.Duration) time.Duration {
+	if d <= 0 || d > maxExecuteTimeout {
+		return maxExecuteTimeout
+	}
+	return d
+}
+
+// parseItemDelay extracts the plain duration component from a (possibly
+// flexible/scheduled) item delay string, e.g. "30s;wd1-5h9-18" -> 30s.
+// It returns 0 if delay has no usable plain duration prefix.
+func parseItemDelay(delay string) time.Duration {
+	s := delay
+	if i := strings.IndexByte(delay, ';'); i != -1 {
+		s = delay[:i]
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// itemTimeout derives a task deadline from an item's update interval,
+// bounded by maxExecuteTimeout.
+func itemTimeout(delay string) time.Duration {
+	d := parseItemDelay(delay)
+	if d <= 0 {
+		return maxExecuteTimeout
+	}
+	return boundTimeout(d)
+}
+
 type taskBase struct {
 	plugin    *pluginAgent
 	scheduled time.Time
 	index     int
 	active    bool
 	recurring bool
+	timeout   time.Duration
+}
+
+// getTimeout returns the task's execution deadline, defaulting to
+// maxExecuteTimeout when the task never had one assigned.
+func (t *taskBase) getTimeout() time.Duration {
+	if t.timeout == 0 {
+		return maxExecuteTimeout
+	}
+	return t.timeout
 }
 
+
 type exporterTaskAccessor interface {
 	task() *exporterTask
 }
@@ -92,14 +148,46 @@ type collectorTask struct {
 	seed uint64
 }
 
+// collectorCtx is the context-aware overload of plugin.Collector. Plugins
+// that implement it can abort in-flight work when the task deadline fires
+// instead of merely being reported as timed out.
+type collectorCtx interface {
+	CollectContext(ctx context.Context) error
+}
+
+func callCollect(ctx context.Context, collector plugin.Collector) error {
+	if cc, ok := collector.(collectorCtx); ok {
+		return cc.CollectContext(ctx)
+	}
+	return collector.Collect()
+}
+
 func (t *collectorTask) perform(s Scheduler) {
-	log.Tracef("plugin %s: executing collector task", t.plugin.name())
+	logger := t.logger()
+	logger.Tracef("executing collector task")
 	go func() {
 		collector, _ := t.plugin.impl.(plugin.Collector)
-		if err := collector.Collect(); err != nil {
-			log.Warningf("plugin '%s' collector failed: %s", t.plugin.impl.Name(), err.Error())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var once sync.Once
+		finish := func() {
+			once.Do(func() {
+				cancel()
+				s.FinishTask(t)
+			})
 		}
-		s.FinishTask(t)
+
+		timer := time.AfterFunc(t.getTimeout(), func() {
+			logger.Warningf("collector task timed out")
+			finish()
+		})
+		defer timer.Stop()
+
+		if err := callCollect(ctx, collector); err != nil {
+			logger.Warningf("collector failed: %s", err.Error())
+		}
+
+		finish()
 	}()
 }
 
@@ -110,6 +198,7 @@ func (t *collectorTask) reschedule(now time.Time) (err error) {
 		return fmt.Errorf("invalid collector interval 0 seconds")
 	}
 	t.scheduled = time.Unix(now.Unix()+int64(t.seed)%int64(period)+1, priorityCollectorTaskNs)
+	t.timeout = boundTimeout(time.Duration(period) * time.Second)
 	return
 }
 
@@ -119,12 +208,43 @@ func (t *collectorTask) getWeight() int {
 
 type exporterTask struct {
 	taskBase
-	item    clientItem
-	failed  bool
-	updated time.Time
-	client  ClientAccessor
-	meta    plugin.Meta
-	output  plugin.ResultWriter
+	item      clientItem
+	failed    bool
+	updated   time.Time
+	client    ClientAccessor
+	meta      plugin.Meta
+	output    plugin.ResultWriter
+	cache     *ResultCache
+	retention time.Duration
+}
+
+// cacheRetention returns how long a cached result remains valid for this
+// task: an explicit per-item Retention override if set, otherwise the
+// plugin's CacheTTL configuration option, otherwise half the item's update
+// interval.
+func (t *exporterTask) cacheRetention(pluginTTL time.Duration) time.Duration {
+	if t.retention > 0 {
+		return t.retention
+	}
+	if pluginTTL > 0 {
+		return pluginTTL
+	}
+	return parseItemDelay(t.item.delay) / 2
+}
+
+// exporterCtx is the context-aware overload of plugin.Exporter. Plugins that
+// implement it (e.g. the systemd plugin via obj.CallWithContext) can cancel
+// in-flight work when the task deadline fires instead of merely being
+// reported as timed out.
+type exporterCtx interface {
+	ExportContext(ctx context.Context, key string, params []string, ctxProvider plugin.ContextProvider) (interface{}, error)
+}
+
+func callExport(ctx context.Context, exporter plugin.Exporter, key string, params []string, ctxProvider plugin.ContextProvider) (interface{}, error) {
+	if ec, ok := exporter.(exporterCtx); ok {
+		return ec.ExportContext(ctx, key, params, ctxProvider)
+	}
+	return exporter.Export(key, params, ctxProvider)
 }
 
 func (t *exporterTask) perform(s Scheduler) {
@@ -137,13 +257,71 @@ func (t *exporterTask) perform(s Scheduler) {
 		var key string
 		var params []string
 		var err error
+		var claimed bool
+		logger := t.Logger()
+
+		cache, pluginTTL := t.cache, time.Duration(0)
+		if cache == nil {
+			cache, pluginTTL = resultCacheFor(t.plugin.impl.Name())
+		}
+
+		timeout := t.getTimeout()
+		// ctx carries the same deadline as the timer below (rather than just
+		// being cancelled when it fires) so a context-aware plugin call can
+		// read ctx.Deadline() and race its own sub-timeout to complete before
+		// the scheduler's does, and still report a useful partial result.
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(timeout))
+		// finished arbitrates between the normal completion path below and the
+		// timeout callback: whichever side wins the CAS is the only one allowed
+		// to write a final result and touch t.failed, so a plugin call that
+		// returns right as the deadline fires can never race the timeout path
+		// onto t.output/t.failed (see the timer below).
+		var finished int32
+		claim := func() bool {
+			return atomic.CompareAndSwapInt32(&finished, 0, 1)
+		}
+		finish := func() {
+			cancel()
+			s.FinishTask(t)
+		}
+
+		timer := time.AfterFunc(timeout, func() {
+			if !claim() {
+				return
+			}
+			logger.Warningf("exporter task timed out")
+			t.failed = true
+			t.output.Write(&plugin.Result{Itemid: t.item.itemid, Error: ErrTimeout, Ts: time.Now()})
+			finish()
+		})
+		defer timer.Stop()
 
 		if key, params, err = itemutil.ParseKey(itemkey); err == nil {
 			var ret interface{}
-			log.Debugf("executing exporter task for itemid:%d key '%s'", t.item.itemid, itemkey)
+			logger.Debugf("executing exporter task")
+
+			var cacheKey resultCacheKey
+			if cache != nil {
+				cacheKey = newResultCacheKey(t.item.itemid, key, params)
+				if cached, ok := cache.Get(cacheKey, t.cacheRetention(pluginTTL)); ok {
+					if !claim() {
+						return
+					}
+					logger.Debugf("serving exporter task from cache")
+					t.output.Write(cached)
+					t.failed = cached.Error != nil
+					finish()
+					return
+				}
+			}
 
-			if ret, err = exporter.Export(key, params, t); err == nil {
-				log.Debugf("executed exporter task for itemid:%d key '%s'", t.item.itemid, itemkey)
+			if ret, err = callExport(ctx, exporter, key, params, t); err == nil {
+				logger.Debugf("executed exporter task")
+				if !claim() {
+					// the timeout already claimed and reported this poll, drop the late value
+					return
+				}
+				claimed = true
 				if ret != nil {
 					rt := reflect.TypeOf(ret)
 					switch rt.Kind() {
@@ -162,6 +340,9 @@ func (t *exporterTask) perform(s Scheduler) {
 					default:
 						result = itemutil.ValueToResult(t.item.itemid, now, ret)
 						t.output.Write(result)
+						if cache != nil && result.Error == nil {
+							cache.Put(cacheKey, result)
+						}
 					}
 				} else {
 					if t.client.ID() == 0 {
@@ -171,9 +352,13 @@ func (t *exporterTask) perform(s Scheduler) {
 					}
 				}
 			} else {
-				log.Debugf("failed to execute exporter task for itemid:%d key '%s' error: '%s'", t.item.itemid, itemkey, err.Error())
+				logger.Debugf("failed to execute exporter task: %s", err.Error())
 			}
 		}
+		if !claimed && !claim() {
+			// the timeout already claimed and reported this poll, drop the late value
+			return
+		}
 		if err != nil {
 			result = &plugin.Result{Itemid: t.item.itemid, Error: err, Ts: now}
 			t.output.Write(result)
@@ -185,7 +370,7 @@ func (t *exporterTask) perform(s Scheduler) {
 			t.failed = false
 		}
 
-		s.FinishTask(t)
+		finish()
 	}(t.item.key)
 }
 
@@ -201,6 +386,7 @@ func (t *exporterTask) reschedule(now time.Time) (err error) {
 		// single passive check
 		t.scheduled = time.Unix(now.Unix(), priorityExporterTaskNs)
 	}
+	t.timeout = itemTimeout(t.item.delay)
 	return
 }
 
@@ -230,16 +416,33 @@ func (t *exporterTask) GlobalRegexp() plugin.RegexpMatcher {
 	return t.client.GlobalRegexp()
 }
 
+func (t *exporterTask) Logger() *taskLogger {
+	return &taskLogger{plugin: t.plugin.impl.Name(), itemid: t.item.itemid, key: t.item.key, clientid: t.client.ID()}
+}
+
 type starterTask struct {
 	taskBase
 }
 
 func (t *starterTask) perform(s Scheduler) {
-	log.Tracef("plugin %s: executing starter task", t.plugin.name())
+	logger := t.logger()
+	logger.Tracef("executing starter task")
 	go func() {
 		runner, _ := t.plugin.impl.(plugin.Runner)
+
+		var once sync.Once
+		finish := func() {
+			once.Do(func() { s.FinishTask(t) })
+		}
+
+		timer := time.AfterFunc(t.getTimeout(), func() {
+			logger.Warningf("start task timed out")
+			finish()
+		})
+		defer timer.Stop()
+
 		runner.Start()
-		s.FinishTask(t)
+		finish()
 	}()
 }
 
@@ -257,11 +460,24 @@ type stopperTask struct {
 }
 
 func (t *stopperTask) perform(s Scheduler) {
-	log.Tracef("plugin %s: executing stopper task", t.plugin.name())
+	logger := t.logger()
+	logger.Tracef("executing stopper task")
 	go func() {
 		runner, _ := t.plugin.impl.(plugin.Runner)
+
+		var once sync.Once
+		finish := func() {
+			once.Do(func() { s.FinishTask(t) })
+		}
+
+		timer := time.AfterFunc(t.getTimeout(), func() {
+			logger.Warningf("stop task timed out")
+			finish()
+		})
+		defer timer.Stop()
+
 		runner.Stop()
-		s.FinishTask(t)
+		finish()
 	}()
 }
 
@@ -280,12 +496,44 @@ type watcherTask struct {
 	client   ClientAccessor
 }
 
+// watcherCtx is the context-aware overload of plugin.Watcher, allowing a
+// plugin to unwind its subscription setup if it is still in progress when
+// the task deadline fires.
+type watcherCtx interface {
+	WatchContext(ctx context.Context, requests []*plugin.Request, ctxProvider plugin.ContextProvider)
+}
+
+func callWatch(ctx context.Context, watcher plugin.Watcher, requests []*plugin.Request, ctxProvider plugin.ContextProvider) {
+	if wc, ok := watcher.(watcherCtx); ok {
+		wc.WatchContext(ctx, requests, ctxProvider)
+		return
+	}
+	watcher.Watch(requests, ctxProvider)
+}
+
 func (t *watcherTask) perform(s Scheduler) {
-	log.Tracef("plugin %s: executing watcher task", t.plugin.name())
+	logger := t.Logger()
+	logger.Tracef("executing watcher task")
 	go func() {
 		watcher, _ := t.plugin.impl.(plugin.Watcher)
-		watcher.Watch(t.requests, t)
-		s.FinishTask(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var once sync.Once
+		finish := func() {
+			once.Do(func() {
+				cancel()
+				s.FinishTask(t)
+			})
+		}
+
+		timer := time.AfterFunc(t.getTimeout(), func() {
+			logger.Warningf("watcher task timed out")
+			finish()
+		})
+		defer timer.Stop()
+
+		callWatch(ctx, watcher, t.requests, t)
+		finish()
 	}()
 }
 
@@ -320,14 +568,22 @@ func (t *watcherTask) GlobalRegexp() plugin.RegexpMatcher {
 	return t.client.GlobalRegexp()
 }
 
+func (t *watcherTask) Logger() *taskLogger {
+	return &taskLogger{plugin: t.plugin.impl.Name(), clientid: t.client.ID()}
+}
+
 type configuratorTask struct {
 	taskBase
 	options map[string]string
 }
 
 func (t *configuratorTask) perform(s Scheduler) {
-	log.Tracef("plugin %s: executing configurator task", t.plugin.name())
+	logger := t.logger()
+	logger.Tracef("executing configurator task")
 	go func() {
+		configureResultCache(t.plugin.impl.Name(), t.options)
+		configurePluginLogLevel(t.plugin.impl.Name(), t.options)
+
 		config, _ := t.plugin.impl.(plugin.Configurator)
 		config.Configure(t.options)
 		s.FinishTask(t)
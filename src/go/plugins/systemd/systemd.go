@@ -20,22 +20,106 @@
 package systemd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"zabbix.com/pkg/plugin"
 
 	"github.com/godbus/dbus"
 )
 
+// dbusJobTimeout caps how long systemd.unit.action waits for the Manager to
+// report a JobRemoved signal for the job it started, when ctx carries no
+// deadline of its own (e.g. a direct call outside the scheduler).
+const dbusJobTimeout = 30 * time.Second
+
+// dbusJobTimeoutMargin is reserved out of ctx's deadline for runJob to
+// notice its own timeout, marshal a structured result and return before the
+// scheduler's own deadline for the task elapses.
+const dbusJobTimeoutMargin = 2 * time.Second
+
+// jobTimeout returns how long runJob should wait for a JobRemoved signal
+// before giving up with its own structured "timeout" result: dbusJobTimeout,
+// or less if ctx's deadline (set by the scheduler to the task's own
+// execution timeout, which may be shorter than dbusJobTimeout for a
+// fast-polled item) would otherwise expire first. Without this, the
+// scheduler's deadline always wins the race and callers get a bare
+// ErrTimeout instead of runJob's {"result":"timeout"} JSON.
+func jobTimeout(ctx context.Context) time.Duration {
+	timeout := dbusJobTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) - dbusJobTimeoutMargin; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout <= 0 {
+		timeout = time.Millisecond
+	}
+	return timeout
+}
+
+// allowedJobModes are the systemd job modes accepted in the third
+// systemd.unit.action parameter, see systemd1.Manager StartUnit docs.
+var allowedJobModes = map[string]bool{
+	"replace":              true,
+	"fail":                 true,
+	"isolate":              true,
+	"ignore-dependencies":  true,
+	"ignore-requirements":  true,
+	"replace-irreversibly": true,
+}
+
 // Plugin -
 type Plugin struct {
 	plugin.Base
-	connections []*dbus.Conn
-	mutex       sync.Mutex
+	connections       []*dbus.Conn
+	mutex             sync.Mutex
+	allowedUnits      []string
+	allowedUnitsMutex sync.Mutex
+	watchConn         *dbus.Conn
+	watchMutex        sync.Mutex
+	watching          bool
+	requests          []*plugin.Request
+}
+
+type jobResult struct {
+	Unit   string `json:"unit"`
+	Job    string `json:"job"`
+	Result string `json:"result"`
+}
+
+// cgroupAccountingProperties are the *Accounting=yes switches that gate
+// whether the matching cgroup counter in cgroup is actually maintained.
+var cgroupAccountingProperties = []string{"CPUAccounting", "MemoryAccounting", "IOAccounting", "TasksAccounting", "IPAccounting"}
+
+type cgroupAccounting struct {
+	CPU    *state `json:"cpu"`
+	Memory *state `json:"memory"`
+	IO     *state `json:"io"`
+}
+
+type cgroup struct {
+	CPUUsageNSec   uint64           `json:"CPUUsageNSec"`
+	MemoryCurrent  uint64           `json:"MemoryCurrent"`
+	MemoryPeak     uint64           `json:"MemoryPeak"`
+	TasksCurrent   uint64           `json:"TasksCurrent"`
+	IPIngressBytes uint64           `json:"IPIngressBytes"`
+	IPEgressBytes  uint64           `json:"IPEgressBytes"`
+	IOReadBytes    uint64           `json:"IOReadBytes"`
+	IOWriteBytes   uint64           `json:"IOWriteBytes"`
+	Accounting     cgroupAccounting `json:"accounting"`
+}
+
+type accountingJson struct {
+	Name    string `json:"{#ACCOUNTING.NAME}"`
+	Enabled string `json:"{#ACCOUNTING.ENABLED}"`
 }
 
 var impl Plugin
@@ -119,6 +203,19 @@ func zbxNum2hex(c byte) byte {
 
 // Export -
 func (p *Plugin) Export(key string, params []string, ctx plugin.ContextProvider) (interface{}, error) {
+	return p.export(context.Background(), key, params, ctx)
+}
+
+// ExportContext is the context-aware overload of plugin.Exporter: it threads
+// ctx into the underlying D-Bus calls (via obj.CallWithContext) so a call
+// still in flight past the task's deadline is actually aborted instead of
+// merely being reported to Zabbix as a timeout while it keeps running
+// untracked.
+func (p *Plugin) ExportContext(ctx context.Context, key string, params []string, ctxp plugin.ContextProvider) (interface{}, error) {
+	return p.export(ctx, key, params, ctxp)
+}
+
+func (p *Plugin) export(ctx context.Context, key string, params []string, ctxp plugin.ContextProvider) (interface{}, error) {
 	conn, err := p.getConnection()
 
 	if nil != err {
@@ -129,17 +226,221 @@ func (p *Plugin) Export(key string, params []string, ctx plugin.ContextProvider)
 
 	switch key {
 	case "systemd.unit.get":
-		return p.get(params, conn)
+		return p.get(ctx, params, conn)
 	case "systemd.unit.discovery":
-		return p.discovery(params, conn)
+		return p.discovery(ctx, params, conn)
 	case "systemd.unit.info":
-		return p.info(params, conn)
+		return p.info(ctx, params, conn)
+	case "systemd.unit.action":
+		return p.action(ctx, params, conn)
+	case "systemd.unit.cgroup":
+		return p.cgroup(ctx, params, conn)
+	case "systemd.unit.cgroup.accounting":
+		return p.cgroupAccounting(ctx, params, conn)
 	default:
 		return nil, plugin.UnsupportedMetricError
 	}
 }
 
-func (p *Plugin) get(params []string, conn *dbus.Conn) (interface{}, error) {
+// Configure implements the plugin.Configurator interface.
+func (p *Plugin) Configure(options map[string]string) {
+	var allowedUnits []string
+
+	if v, ok := options["ActionAllowList"]; ok && len(v) != 0 {
+		for _, u := range strings.Split(v, ",") {
+			if u = strings.TrimSpace(u); len(u) != 0 {
+				allowedUnits = append(allowedUnits, u)
+			}
+		}
+	}
+
+	p.allowedUnitsMutex.Lock()
+	p.allowedUnits = allowedUnits
+	p.allowedUnitsMutex.Unlock()
+}
+
+func (p *Plugin) isActionAllowed(unitName string) bool {
+	p.allowedUnitsMutex.Lock()
+	defer p.allowedUnitsMutex.Unlock()
+
+	for _, u := range p.allowedUnits {
+		if u == "*" || u == unitName {
+			return true
+		}
+	}
+	return false
+}
+
+// unitSignalMatches are the D-Bus match rules registered once per connection
+// by Watch() to receive unit and job state transitions without polling.
+var unitSignalMatches = []string{
+	"type='signal',interface='org.freedesktop.systemd1.Manager',member='UnitNew'",
+	"type='signal',interface='org.freedesktop.systemd1.Manager',member='UnitRemoved'",
+	"type='signal',interface='org.freedesktop.systemd1.Manager',member='JobNew'",
+	"type='signal',interface='org.freedesktop.systemd1.Manager',member='JobRemoved'",
+	"type='signal',interface='org.freedesktop.systemd1.Unit',member='PropertiesChanged'",
+}
+
+// Watch implements the plugin.Watcher interface. It subscribes to the
+// systemd Manager once and translates unit/job signals into
+// systemd.unit.state.changed[unit] and systemd.unit.job.finished[unit]
+// results for as long as the plugin is watched. A repeated Watch() call,
+// e.g. on reconfigure, only replaces the set of watched requests while the
+// subscription is still alive; it does not subscribe again.
+func (p *Plugin) Watch(requests []*plugin.Request, ctx plugin.ContextProvider) {
+	p.WatchContext(context.Background(), requests, ctx)
+}
+
+// WatchContext is the context-aware overload of plugin.Watcher: ctx bounds
+// only the initial Subscribe/AddMatch calls (via obj.CallWithContext), not
+// the signal stream itself, so a watcher task whose deadline fires while
+// subscribing aborts that D-Bus call instead of wedging it forever.
+func (p *Plugin) WatchContext(ctx context.Context, requests []*plugin.Request, ctxp plugin.ContextProvider) {
+	p.watchMutex.Lock()
+	defer p.watchMutex.Unlock()
+
+	p.requests = requests
+
+	if p.watching {
+		return
+	}
+
+	conn, err := p.getConnection()
+	if err != nil {
+		p.Errf("Cannot establish connection to any available bus: %s", err)
+		return
+	}
+
+	if err := p.subscribeUnitSignals(ctx, conn); err != nil {
+		p.Errf("Cannot subscribe to systemd unit signals: %s", err)
+		p.releaseConnection(conn)
+		return
+	}
+
+	p.watchConn = conn
+	p.watching = true
+
+	go p.watch(conn, ctxp)
+}
+
+func (p *Plugin) subscribeUnitSignals(ctx context.Context, conn *dbus.Conn) error {
+	manager := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1"))
+	if call := manager.CallWithContext(ctx, "org.freedesktop.systemd1.Manager.Subscribe", 0); call.Err != nil {
+		return call.Err
+	}
+
+	for _, rule := range unitSignalMatches {
+		if call := conn.BusObject().CallWithContext(ctx, "org.freedesktop.DBus.AddMatch", 0, rule); call.Err != nil {
+			return call.Err
+		}
+	}
+
+	return nil
+}
+
+func (p *Plugin) unsubscribeUnitSignals(conn *dbus.Conn) {
+	for _, rule := range unitSignalMatches {
+		conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, rule)
+	}
+	conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1")).
+		Call("org.freedesktop.systemd1.Manager.Unsubscribe", 0)
+}
+
+// watch runs in its own goroutine for the lifetime of the subscription,
+// draining signals delivered by conn.Signal() and never invoked directly.
+func (p *Plugin) watch(conn *dbus.Conn, ctx plugin.ContextProvider) {
+	ch := make(chan *dbus.Signal, 256)
+	conn.Signal(ch)
+
+	defer func() {
+		conn.RemoveSignal(ch)
+		p.unsubscribeUnitSignals(conn)
+
+		p.watchMutex.Lock()
+		p.watching = false
+		p.watchConn = nil
+		p.watchMutex.Unlock()
+
+		p.releaseConnection(conn)
+	}()
+
+	for sig := range ch {
+		p.handleUnitSignal(sig, ctx)
+	}
+}
+
+func (p *Plugin) handleUnitSignal(sig *dbus.Signal, ctx plugin.ContextProvider) {
+	switch sig.Name {
+	case "org.freedesktop.systemd1.Manager.JobRemoved":
+		if len(sig.Body) < 4 {
+			return
+		}
+
+		unitName, ok := sig.Body[2].(string)
+		if !ok {
+			return
+		}
+
+		result, ok := sig.Body[3].(string)
+		if !ok {
+			return
+		}
+
+		p.writeResult(ctx, "systemd.unit.job.finished", unitName, result)
+	case "org.freedesktop.systemd1.Manager.UnitNew", "org.freedesktop.systemd1.Manager.UnitRemoved":
+		if len(sig.Body) < 1 {
+			return
+		}
+
+		unitName, ok := sig.Body[0].(string)
+		if !ok {
+			return
+		}
+
+		p.writeResult(ctx, "systemd.unit.state.changed", unitName, sig.Name)
+	case "org.freedesktop.systemd1.Unit.PropertiesChanged":
+		unitName := unescapeName(filepath.Base(string(sig.Path)))
+		p.writeResult(ctx, "systemd.unit.state.changed", unitName, sig.Name)
+	}
+}
+
+// writeResult matches the signal against the items currently being watched
+// and writes a result only for the matching systemd.unit.state.changed[unit]
+// or systemd.unit.job.finished[unit] item, mirroring how exporterTask keys
+// results by itemid.
+func (p *Plugin) writeResult(ctx plugin.ContextProvider, keyName, unitName, value string) {
+	itemid, ok := p.matchRequest(keyName, unitName)
+	if !ok {
+		return
+	}
+
+	ctx.Output().Write(&plugin.Result{Itemid: itemid, Value: &value, Ts: time.Now()})
+}
+
+func (p *Plugin) matchRequest(keyName, unitName string) (itemid uint64, ok bool) {
+	p.watchMutex.Lock()
+	defer p.watchMutex.Unlock()
+
+	for _, r := range p.requests {
+		name, param := splitKeyParam(r.Key)
+		if name == keyName && param == unitName {
+			return r.Itemid, true
+		}
+	}
+
+	return 0, false
+}
+
+func splitKeyParam(key string) (name, param string) {
+	i := strings.IndexByte(key, '[')
+	if i < 0 {
+		return key, ""
+	}
+
+	return key[:i], strings.TrimSuffix(key[i+1:], "]")
+}
+
+func (p *Plugin) get(ctx context.Context, params []string, conn *dbus.Conn) (interface{}, error) {
 	var property, unitType string
 	var values map[string]interface{}
 
@@ -162,7 +463,7 @@ func (p *Plugin) get(params []string, conn *dbus.Conn) (interface{}, error) {
 	}
 
 	obj := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1/unit/"+getName(params[0])))
-	err := obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, "org.freedesktop.systemd1."+unitType, property).Store(&values)
+	err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.GetAll", 0, "org.freedesktop.systemd1."+unitType, property).Store(&values)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot get unit property: %s", err)
 	}
@@ -188,7 +489,7 @@ func (p *Plugin) get(params []string, conn *dbus.Conn) (interface{}, error) {
 	return string(val), nil
 }
 
-func (p *Plugin) discovery(params []string, conn *dbus.Conn) (interface{}, error) {
+func (p *Plugin) discovery(ctx context.Context, params []string, conn *dbus.Conn) (interface{}, error) {
 	var ext string
 
 	if len(params) > 1 {
@@ -210,7 +511,7 @@ func (p *Plugin) discovery(params []string, conn *dbus.Conn) (interface{}, error
 
 	var units []unit
 	obj := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1"))
-	err := obj.Call("org.freedesktop.systemd1.Manager.ListUnits", 0).Store(&units)
+	err := obj.CallWithContext(ctx, "org.freedesktop.systemd1.Manager.ListUnits", 0).Store(&units)
 
 	if nil != err {
 		return nil, fmt.Errorf("Cannot retrieve list of units: %s", err)
@@ -222,9 +523,9 @@ func (p *Plugin) discovery(params []string, conn *dbus.Conn) (interface{}, error
 			continue
 		}
 
-		UnitFileState, err := p.info([]string{u.Name, "UnitFileState"}, conn)
+		UnitFileState, err := p.info(ctx, []string{u.Name, "UnitFileState"}, conn)
 		if err != nil {
-			p.Debugf("Failed to retrieve unit file state for %s, err:", u.Name, err.Error())
+			p.Warningf("plugin=systemd unit=%s msg=\"failed to retrieve unit file state\" error=%q", u.Name, err.Error())
 			continue
 		}
 
@@ -233,7 +534,7 @@ func (p *Plugin) discovery(params []string, conn *dbus.Conn) (interface{}, error
 		case reflect.String:
 			state = UnitFileState.(string)
 		default:
-			p.Debugf("Unit file state is not string for %s", u.Name)
+			p.Warningf("plugin=systemd unit=%s msg=\"unit file state is not a string\"", u.Name)
 			continue
 		}
 
@@ -250,7 +551,7 @@ func (p *Plugin) discovery(params []string, conn *dbus.Conn) (interface{}, error
 	return string(jsonArray), nil
 }
 
-func (p *Plugin) info(params []string, conn *dbus.Conn) (interface{}, error) {
+func (p *Plugin) info(ctx context.Context, params []string, conn *dbus.Conn) (interface{}, error) {
 	var property, unitType string
 	var value interface{}
 
@@ -275,7 +576,7 @@ func (p *Plugin) info(params []string, conn *dbus.Conn) (interface{}, error) {
 	}
 
 	obj := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1/unit/"+getName(params[0])))
-	err := obj.Call("org.freedesktop.DBus.Properties.Get", 0, "org.freedesktop.systemd1."+unitType, property).Store(&value)
+	err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0, "org.freedesktop.systemd1."+unitType, property).Store(&value)
 
 	if nil != err {
 		return nil, fmt.Errorf("Cannot get unit property: %s", err)
@@ -297,6 +598,247 @@ func (p *Plugin) info(params []string, conn *dbus.Conn) (interface{}, error) {
 	return value, nil
 }
 
+func (p *Plugin) cgroup(ctx context.Context, params []string, conn *dbus.Conn) (interface{}, error) {
+	values, err := p.getServiceProperties(ctx, params, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	cg := cgroup{
+		CPUUsageNSec:   toUint64(values["CPUUsageNSec"]),
+		MemoryCurrent:  toUint64(values["MemoryCurrent"]),
+		MemoryPeak:     toUint64(values["MemoryPeak"]),
+		TasksCurrent:   toUint64(values["TasksCurrent"]),
+		IPIngressBytes: toUint64(values["IPIngressBytes"]),
+		IPEgressBytes:  toUint64(values["IPEgressBytes"]),
+		IOReadBytes:    toUint64(values["IOReadBytes"]),
+		IOWriteBytes:   toUint64(values["IOWriteBytes"]),
+		Accounting: cgroupAccounting{
+			CPU:    boolState(toBool(values["CPUAccounting"])),
+			Memory: boolState(toBool(values["MemoryAccounting"])),
+			IO:     boolState(toBool(values["IOAccounting"])),
+		},
+	}
+
+	val, err := json.Marshal(cg)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create JSON array: %s", err)
+	}
+
+	return string(val), nil
+}
+
+func (p *Plugin) cgroupAccounting(ctx context.Context, params []string, conn *dbus.Conn) (interface{}, error) {
+	values, err := p.getServiceProperties(ctx, params, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var array []accountingJson
+	for _, prop := range cgroupAccountingProperties {
+		array = append(array, accountingJson{
+			Name:    strings.TrimSuffix(prop, "Accounting"),
+			Enabled: strconv.FormatBool(toBool(values[prop])),
+		})
+	}
+
+	jsonArray, err := json.Marshal(array)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create JSON array: %s", err)
+	}
+
+	return string(jsonArray), nil
+}
+
+// cgroupAccountingInterfaces maps a unit's filepath.Ext suffix to the D-Bus
+// interface that exposes its cgroup accounting properties: Service, Scope
+// and Slice units each maintain the same CPUUsageNSec/MemoryCurrent/IO*/IP*
+// counters, but under their own interface rather than Service's.
+var cgroupAccountingInterfaces = map[string]string{
+	".service": "org.freedesktop.systemd1.Service",
+	".scope":   "org.freedesktop.systemd1.Scope",
+	".slice":   "org.freedesktop.systemd1.Slice",
+}
+
+func (p *Plugin) getServiceProperties(ctx context.Context, params []string, conn *dbus.Conn) (map[string]interface{}, error) {
+	if len(params) > 1 {
+		return nil, fmt.Errorf("Too many parameters.")
+	}
+
+	if len(params) < 1 || len(params[0]) == 0 {
+		return nil, fmt.Errorf("Invalid first parameter.")
+	}
+
+	iface, ok := cgroupAccountingInterfaces[filepath.Ext(params[0])]
+	if !ok {
+		return nil, fmt.Errorf("Unit '%s' does not support cgroup accounting.", params[0])
+	}
+
+	var values map[string]interface{}
+	obj := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1/unit/"+getName(params[0])))
+	err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.GetAll", 0, iface).Store(&values)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get unit property: %s", err)
+	}
+
+	return values, nil
+}
+
+func toUint64(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		return uint64(n)
+	case uint32:
+		return uint64(n)
+	case int32:
+		return uint64(n)
+	default:
+		return 0
+	}
+}
+
+func toBool(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func boolState(v bool) *state {
+	if v {
+		return &state{1, "true"}
+	}
+	return &state{0, "false"}
+}
+
+func (p *Plugin) action(ctx context.Context, params []string, conn *dbus.Conn) (interface{}, error) {
+	if len(params) > 3 {
+		return nil, fmt.Errorf("Too many parameters.")
+	}
+
+	if len(params) < 2 || len(params[0]) == 0 || len(params[1]) == 0 {
+		return nil, fmt.Errorf("Too few parameters.")
+	}
+
+	unitName := params[0]
+	action := params[1]
+
+	mode := "replace"
+	if len(params) == 3 && len(params[2]) != 0 {
+		mode = params[2]
+	}
+
+	if !allowedJobModes[mode] {
+		return nil, fmt.Errorf("Invalid job mode '%s'.", mode)
+	}
+
+	if !p.isActionAllowed(unitName) {
+		return nil, fmt.Errorf("Action on unit '%s' is not permitted by the ActionAllowList.", unitName)
+	}
+
+	switch action {
+	case "start":
+		return p.runJob(ctx, conn, "StartUnit", unitName, mode)
+	case "stop":
+		return p.runJob(ctx, conn, "StopUnit", unitName, mode)
+	case "restart":
+		return p.runJob(ctx, conn, "RestartUnit", unitName, mode)
+	case "reload":
+		return p.runJob(ctx, conn, "ReloadUnit", unitName, mode)
+	case "enable":
+		return p.enableUnit(ctx, conn, unitName)
+	case "disable":
+		return p.disableUnit(ctx, conn, unitName)
+	default:
+		return nil, fmt.Errorf("Invalid action '%s'.", action)
+	}
+}
+
+// runJob calls the given systemd1.Manager unit job method, subscribes to the
+// returned job's JobRemoved signal and waits for it with a bounded timeout.
+// It also gives up as soon as ctx is done, so a job stuck past the task's
+// own deadline is abandoned instead of leaking this goroutine until
+// dbusJobTimeout elapses.
+func (p *Plugin) runJob(ctx context.Context, conn *dbus.Conn, method, unitName, mode string) (interface{}, error) {
+	manager := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1"))
+
+	ch := make(chan *dbus.Signal, 1)
+	conn.Signal(ch)
+	defer conn.RemoveSignal(ch)
+
+	matchRule := "type='signal',interface='org.freedesktop.systemd1.Manager',member='JobRemoved'"
+	if call := conn.BusObject().CallWithContext(ctx, "org.freedesktop.DBus.AddMatch", 0, matchRule); call.Err != nil {
+		return nil, fmt.Errorf("Cannot subscribe to job signals: %s", call.Err)
+	}
+	defer conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, matchRule)
+
+	var jobPath dbus.ObjectPath
+	if err := manager.CallWithContext(ctx, "org.freedesktop.systemd1.Manager."+method, 0, unitName, mode).Store(&jobPath); err != nil {
+		return nil, fmt.Errorf("Cannot %s unit '%s': %s", method, unitName, err)
+	}
+
+	timeout := time.NewTimer(jobTimeout(ctx))
+	defer timeout.Stop()
+
+	for {
+		select {
+		case sig := <-ch:
+			if sig.Name != "org.freedesktop.systemd1.Manager.JobRemoved" || len(sig.Body) < 4 {
+				continue
+			}
+			if path, ok := sig.Body[1].(dbus.ObjectPath); !ok || path != jobPath {
+				continue
+			}
+
+			result, ok := sig.Body[3].(string)
+			if !ok {
+				return nil, fmt.Errorf("Cannot parse job result for unit '%s'.", unitName)
+			}
+
+			return p.marshalJobResult(unitName, jobPath, result)
+		case <-timeout.C:
+			return p.marshalJobResult(unitName, jobPath, "timeout")
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Cancelled waiting for job result for unit '%s': %s", unitName, ctx.Err())
+		}
+	}
+}
+
+func (p *Plugin) enableUnit(ctx context.Context, conn *dbus.Conn, unitName string) (interface{}, error) {
+	manager := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1"))
+
+	var carryRemove bool
+	var changes [][]interface{}
+	err := manager.CallWithContext(ctx, "org.freedesktop.systemd1.Manager.EnableUnitFiles", 0, []string{unitName}, false, true).
+		Store(&carryRemove, &changes)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot enable unit '%s': %s", unitName, err)
+	}
+
+	return p.marshalJobResult(unitName, "", "done")
+}
+
+func (p *Plugin) disableUnit(ctx context.Context, conn *dbus.Conn, unitName string) (interface{}, error) {
+	manager := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1"))
+
+	var changes [][]interface{}
+	err := manager.CallWithContext(ctx, "org.freedesktop.systemd1.Manager.DisableUnitFiles", 0, []string{unitName}, false).Store(&changes)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot disable unit '%s': %s", unitName, err)
+	}
+
+	return p.marshalJobResult(unitName, "", "done")
+}
+
+func (p *Plugin) marshalJobResult(unitName string, jobPath dbus.ObjectPath, result string) (interface{}, error) {
+	val, err := json.Marshal(jobResult{Unit: unitName, Job: string(jobPath), Result: result})
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create JSON array: %s", err)
+	}
+
+	return string(val), nil
+}
+
 func getName(name string) string {
 	nameEsc := make([]byte, len(name)*3)
 	j := 0
@@ -318,52 +860,99 @@ func getName(name string) string {
 	return string(nameEsc[:j])
 }
 
+// unescapeName reverses getName: each "_XY" hex escape decodes back to the
+// original byte, turning a D-Bus object path segment such as
+// "sshd_2eservice" back into the literal unit name "sshd.service".
+func unescapeName(escaped string) string {
+	var b strings.Builder
+	b.Grow(len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '_' && i+2 < len(escaped) {
+			if hi, ok := hexNibble(escaped[i+1]); ok {
+				if lo, ok := hexNibble(escaped[i+2]); ok {
+					b.WriteByte(hi<<4 | lo)
+					i += 2
+					continue
+				}
+			}
+		}
+		b.WriteByte(escaped[i])
+	}
+	return b.String()
+}
+
+func hexNibble(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+// warnProperty logs a structured warning when a unit property cannot be
+// type-asserted into the shape setUnitStates/setServiceStates/setSocketStates
+// expect, so a host with hundreds of units can filter or aggregate on the
+// property field instead of parsing free-form text. It goes through
+// plugin.Base.Warningf, the agent's one shared log sink, rather than the
+// scheduler's per-plugin LogLevel filtering (see configurePluginLogLevel):
+// that filtering only covers lines the scheduler logs about a task, and
+// extending it to a plugin's own internal logging would need a Logger
+// exposed on plugin.ContextProvider, which lives outside this source tree.
+func (p *Plugin) warnProperty(property string) {
+	p.Warningf("plugin=systemd property=%s msg=\"cannot format unit property for response\"", property)
+}
+
 func (p *Plugin) setUnitStates(v map[string]interface{}) {
 	loadState, ok := v["LoadState"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "LoadState")
+		p.warnProperty("LoadState")
 	}
 	v["LoadState"] = createState([]string{"loaded", "error", "masked"}, loadState)
 
 	activeState, ok := v["ActiveState"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "ActiveState")
+		p.warnProperty("ActiveState")
 	}
 	v["ActiveState"] = createState([]string{"active", "reloading", "inactive", "failed", "activating", "deactivating"}, activeState)
 
 	unitFileState, ok := v["UnitFileState"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "UnitFileState")
+		p.warnProperty("UnitFileState")
 	}
 	v["UnitFileState"] = createState([]string{"enabled", "enabled-runtime", "linked", "linked-runtime", "masked", "masked-runtime", "static", "disabled", "invalid"}, unitFileState)
 
 	onFailureJobMode, ok := v["OnFailureJobMode"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "OnFailureJobMode")
+		p.warnProperty("OnFailureJobMode")
 	}
 	v["OnFailureJobMode"] = createState([]string{"fail", "replace", "replace-irreversibly", "isolate", "flush", "ignore-dependencies", "ignore-requirements"}, onFailureJobMode)
 
 	collectMode, ok := v["CollectMode"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "CollectMode")
+		p.warnProperty("CollectMode")
 	}
 	v["CollectMode"] = createState([]string{"inactive, inactive-or-failed"}, collectMode)
 
 	startLimitAction, ok := v["StartLimitAction"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "StartLimitAction")
+		p.warnProperty("StartLimitAction")
 	}
 	v["StartLimitAction"] = createState([]string{"none", "reboot", "reboot-force", "reboot-immediate", "poweroff", "poweroff-force", "poweroff-immediate", "exit", "exit-force"}, startLimitAction)
 
 	failureAction, ok := v["FailureAction"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "FailureAction")
+		p.warnProperty("FailureAction")
 	}
 	v["FailureAction"] = createState([]string{"none", "reboot", "reboot-force", "reboot-immediate", "poweroff", "poweroff-force", "poweroff-immediate", "exit", "exit-force"}, failureAction)
 
 	successAction, ok := v["SuccessAction"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "SuccessAction")
+		p.warnProperty("SuccessAction")
 	}
 	v["SuccessAction"] = createState([]string{"none", "reboot", "reboot-force", "reboot-immediate", "poweroff", "poweroff-force", "poweroff-immediate", "exit", "exit-force"}, successAction)
 }
@@ -371,19 +960,19 @@ func (p *Plugin) setUnitStates(v map[string]interface{}) {
 func (p *Plugin) setServiceStates(v map[string]interface{}) {
 	notifyAccess, ok := v["NotifyAccess"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "NotifyAccess")
+		p.warnProperty("NotifyAccess")
 	}
 	v["NotifyAccess"] = createState([]string{"none", "main", "exec", "all"}, notifyAccess)
 
 	restart, ok := v["Restart"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "Restart")
+		p.warnProperty("Restart")
 	}
 	v["Restart"] = createState([]string{"no", "on-success", "on-failure", "on-abnormal", "on-watchdog", "on-abort", "always"}, restart)
 
 	t, ok := v["Type"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "Type")
+		p.warnProperty("Type")
 	}
 	v["Type"] = createState([]string{"simple", "exec", "forking", "oneshot", "dbus", "notify", "idle"}, t)
 }
@@ -391,13 +980,13 @@ func (p *Plugin) setServiceStates(v map[string]interface{}) {
 func (p *Plugin) setSocketStates(v map[string]interface{}) {
 	bindIPv6Only, ok := v["BindIPv6Only"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "BindIPv6Only")
+		p.warnProperty("BindIPv6Only")
 	}
 	v["BindIPv6Only"] = createState([]string{"default", " both", "ipv6-only"}, bindIPv6Only)
 
 	timestamping, ok := v["Timestamping"].(string)
 	if !ok {
-		p.Debugf("Cannot format '%s' unit property for a response.", "Timestamping")
+		p.warnProperty("Timestamping")
 	}
 	v["Timestamping"] = createState([]string{"off", "us", "usec", "µs", "poweroff", "ns", "nsec"}, timestamping)
 }
@@ -417,5 +1006,10 @@ func init() {
 		"systemd.unit.get", "Returns the bulked info, usage: systemd.unit.get[unit,<interface>].",
 		"systemd.unit.discovery", "Returns JSON array of discovered units, usage: systemd.unit.discovery[<type>].",
 		"systemd.unit.info", "Returns the unit info, usage: systemd.unit.info[unit,<parameter>,<interface>].",
+		"systemd.unit.action", "Performs a unit action, usage: systemd.unit.action[unit,action,<mode>].",
+		"systemd.unit.state.changed", "Returns the unit state on change, usage: systemd.unit.state.changed[unit].",
+		"systemd.unit.job.finished", "Returns the job result when a unit job completes, usage: systemd.unit.job.finished[unit].",
+		"systemd.unit.cgroup", "Returns the unit cgroup resource accounting, usage: systemd.unit.cgroup[unit].",
+		"systemd.unit.cgroup.accounting", "Returns JSON array of cgroup accounting flags and whether each is enabled, usage: systemd.unit.cgroup.accounting[unit].",
 	)
 }